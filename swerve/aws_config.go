@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3API is the subset of *s3.Client that Swerve's S3 rule-loading code
+// needs. *s3.Client satisfies it automatically; tests substitute a stub to
+// exercise loadRedirectsFromS3 and friends without talking to AWS.
+type s3API interface {
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// newS3Client builds an s3API from opts' AWS credential settings. It is a
+// var, not a plain function, so tests can replace it with a stub client.
+var newS3Client = func(ctx context.Context, opts Options) (s3API, error) {
+	cfg, err := loadAWSConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s3.NewFromConfig(cfg), nil
+}
+
+// loadAWSConfig builds the AWS config shared by every S3/SQS client Swerve
+// creates, honouring the static-credentials, anonymous, profile and region
+// settings on opts. AWSAnonymous takes precedence over static credentials,
+// which take precedence over the default chain; AWSProfile only affects the
+// default chain.
+func loadAWSConfig(ctx context.Context, opts Options) (aws.Config, error) {
+	var cfgOptions []func(*config.LoadOptions) error
+
+	switch {
+	case opts.AWSAnonymous:
+		log.Println("Using anonymous AWS credentials (no signing).")
+		cfgOptions = append(cfgOptions, config.WithCredentialsProvider(aws.AnonymousCredentials{}))
+	case opts.AWSAccessKeyID != "" && opts.AWSSecretAccessKey != "":
+		log.Println("Using static AWS credentials.")
+		creds := credentials.NewStaticCredentialsProvider(opts.AWSAccessKeyID, opts.AWSSecretAccessKey, opts.AWSSessionToken)
+		cfgOptions = append(cfgOptions, config.WithCredentialsProvider(creds))
+	case opts.AWSProfile != "":
+		log.Printf("Using AWS shared-config profile %q.", opts.AWSProfile)
+		cfgOptions = append(cfgOptions, config.WithSharedConfigProfile(opts.AWSProfile))
+	default:
+		log.Println("Using default AWS credential chain (e.g., IAM role).")
+	}
+
+	if opts.AWSRegion != "" {
+		cfgOptions = append(cfgOptions, config.WithRegion(opts.AWSRegion))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, cfgOptions...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return cfg, nil
+}
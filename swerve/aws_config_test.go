@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestLoadAWSConfig_Anonymous(t *testing.T) {
+	cfg, err := loadAWSConfig(context.Background(), Options{AWSAnonymous: true, AWSRegion: "us-east-1"})
+	if err != nil {
+		t.Fatalf("loadAWSConfig: %v", err)
+	}
+	// LoadDefaultConfig wraps the provider in an aws.CredentialsCache, so
+	// look through the wrapper rather than type-asserting on it.
+	if !aws.IsCredentialsProvider(cfg.Credentials, aws.AnonymousCredentials{}) {
+		t.Fatalf("expected anonymous credentials, got %T", cfg.Credentials)
+	}
+}
+
+func TestLoadAWSConfig_StaticCredentials(t *testing.T) {
+	cfg, err := loadAWSConfig(context.Background(), Options{
+		AWSAccessKeyID:     "AKIDEXAMPLE",
+		AWSSecretAccessKey: "secret",
+		AWSSessionToken:    "token",
+		AWSRegion:          "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("loadAWSConfig: %v", err)
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if creds.AccessKeyID != "AKIDEXAMPLE" || creds.SecretAccessKey != "secret" || creds.SessionToken != "token" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+}
+
+func TestLoadAWSConfig_Profile(t *testing.T) {
+	// Profile resolution only takes effect through the default credential
+	// chain's shared-config loading, so the useful thing to assert here is
+	// that AWSProfile doesn't get short-circuited by the anonymous or
+	// static-credentials branches. An explicitly named profile must exist in
+	// the shared config, so point the SDK at a temporary one that defines it.
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	if err := os.WriteFile(configFile, []byte("[profile swerve-test]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatalf("writing shared config: %v", err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	cfg, err := loadAWSConfig(context.Background(), Options{AWSProfile: "swerve-test", AWSRegion: "us-east-1"})
+	if err != nil {
+		t.Fatalf("loadAWSConfig: %v", err)
+	}
+	if aws.IsCredentialsProvider(cfg.Credentials, aws.AnonymousCredentials{}) {
+		t.Fatalf("expected non-anonymous credentials when AWSProfile is set")
+	}
+}
+
+func TestLoadAWSConfig_DefaultChain(t *testing.T) {
+	cfg, err := loadAWSConfig(context.Background(), Options{AWSRegion: "us-east-1"})
+	if err != nil {
+		t.Fatalf("loadAWSConfig: %v", err)
+	}
+	if aws.IsCredentialsProvider(cfg.Credentials, aws.AnonymousCredentials{}) {
+		t.Fatalf("expected default credential chain, got anonymous credentials")
+	}
+	if cfg.Region != "us-east-1" {
+		t.Fatalf("expected region us-east-1, got %q", cfg.Region)
+	}
+}
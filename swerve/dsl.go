@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// dslEnv holds the goja runtime and accumulated rules for one evaluation of a
+// JS rule config, including any files it pulls in via Include(). All scripts
+// run in the same runtime so Include()d files share the host's D/Redirect/
+// RegexRedirect/PathPrefix bindings.
+type dslEnv struct {
+	vm      *goja.Runtime
+	baseDir string
+	source  string
+	rules   map[string][]Redirect
+	count   int
+	issues  []LoadIssue
+}
+
+// newDSLEnv builds a runtime with the Domain/Rule helpers bound and ready to
+// evaluate scripts found under baseDir.
+func newDSLEnv(baseDir, source string) *dslEnv {
+	env := &dslEnv{
+		vm:      goja.New(),
+		baseDir: baseDir,
+		source:  source,
+		rules:   make(map[string][]Redirect),
+	}
+	env.vm.Set("D", env.domain)
+	env.vm.Set("Redirect", env.redirect)
+	env.vm.Set("RegexRedirect", env.regexRedirect)
+	env.vm.Set("PathPrefix", env.pathPrefix)
+	env.vm.Set("Include", env.include)
+	return env
+}
+
+// newRuleObject builds the plain rule object returned to JS by Redirect(),
+// RegexRedirect() and PathPrefix(), before it is attached to a host via D().
+func newRuleObject(matchType, source, target string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       matchType,
+		"source":     source,
+		"target":     target,
+		"statusCode": 301,
+		"weight":     0,
+	}
+}
+
+// applyRuleOptions folds the optional trailing arguments of Redirect(),
+// RegexRedirect() and PathPrefix() into rule: either a bare status code
+// (Redirect("/a", "/b", 302)) or an options object (Redirect("/a", "/b",
+// {weight: 10, statusCode: 302})).
+func applyRuleOptions(rule map[string]interface{}, args []goja.Value) {
+	for _, arg := range args {
+		switch v := arg.Export().(type) {
+		case int64:
+			rule["statusCode"] = int(v)
+		case float64:
+			rule["statusCode"] = int(v)
+		case map[string]interface{}:
+			if sc, ok := v["statusCode"]; ok {
+				rule["statusCode"] = toInt(sc)
+			}
+			if w, ok := v["weight"]; ok {
+				rule["weight"] = toInt(w)
+			}
+		}
+	}
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	default:
+		return 0
+	}
+}
+
+func (e *dslEnv) redirect(source, target string, args ...goja.Value) map[string]interface{} {
+	rule := newRuleObject("exact", source, target)
+	applyRuleOptions(rule, args)
+	return rule
+}
+
+func (e *dslEnv) regexRedirect(source, target string, args ...goja.Value) map[string]interface{} {
+	rule := newRuleObject("regex", source, target)
+	applyRuleOptions(rule, args)
+	return rule
+}
+
+// pathPrefix is sugar for a regex rule that matches everything under prefix
+// (written with a trailing "*", e.g. "/blog/*") and forwards the captured
+// remainder to target via "$1".
+func (e *dslEnv) pathPrefix(prefix, target string, args ...goja.Value) map[string]interface{} {
+	base := strings.TrimSuffix(prefix, "*")
+	source := "^" + regexp.QuoteMeta(base) + "(.*)$"
+	rule := newRuleObject("regex", source, target)
+	applyRuleOptions(rule, args)
+	return rule
+}
+
+// domain attaches one or more rules (or arrays of rules) produced by
+// Redirect/RegexRedirect/PathPrefix to host, mirroring dnscontrol's D(...).
+func (e *dslEnv) domain(host string, args ...goja.Value) {
+	for _, arg := range args {
+		e.collectRule(host, arg.Export())
+	}
+}
+
+func (e *dslEnv) collectRule(host string, v interface{}) {
+	switch rv := v.(type) {
+	case []interface{}:
+		for _, item := range rv {
+			e.collectRule(host, item)
+		}
+	case map[string]interface{}:
+		rule, err := ruleFromObject(host, e.source, rv)
+		if err != nil {
+			e.issues = append(e.issues, logIssue(e.source, "DSL %s: skipping invalid rule for %s: %v", e.source, host, err))
+			return
+		}
+		e.rules[host] = append(e.rules[host], rule)
+		e.count++
+	default:
+		e.issues = append(e.issues, logIssue(e.source, "DSL %s: skipping unrecognised rule value for %s: %v", e.source, host, v))
+	}
+}
+
+// ruleFromObject converts a JS rule object (as produced by newRuleObject) into
+// the same Redirect type the CSV loader produces, so loadRules stays format
+// agnostic.
+func ruleFromObject(host, source string, m map[string]interface{}) (Redirect, error) {
+	matchType, _ := m["type"].(string)
+	sourcePath, _ := m["source"].(string)
+	target, _ := m["target"].(string)
+
+	rule := Redirect{
+		SourceHost:        host,
+		MatchType:         matchType,
+		SourcePathOrRegex: sourcePath,
+		TargetURLFormat:   target,
+		StatusCode:        toInt(m["statusCode"]),
+		Weight:            toInt(m["weight"]),
+		Source:            source,
+	}
+
+	if matchType == "regex" {
+		re, err := regexp.Compile(sourcePath)
+		if err != nil {
+			return Redirect{}, fmt.Errorf("invalid regex %q: %w", sourcePath, err)
+		}
+		rule.Regex = re
+	}
+
+	return rule, nil
+}
+
+// include evaluates another script, relative to baseDir unless it's already
+// absolute, in the same runtime so it can call D(...) itself.
+func (e *dslEnv) include(path string) {
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(e.baseDir, full)
+	}
+
+	src, err := os.ReadFile(full)
+	if err != nil {
+		log.Printf("DSL %s: Include(%q) failed: %v", e.source, path, err)
+		return
+	}
+	if _, err := e.vm.RunScript(full, string(src)); err != nil {
+		log.Printf("DSL %s: error evaluating included script %s: %v", e.source, full, err)
+	}
+}
+
+// loadRedirectsFromJS evaluates a single .js rule file (and any scripts it
+// Include()s) and returns the rules collected via D(...), keyed by host.
+func loadRedirectsFromJS(path string) (map[string][]Redirect, int, []LoadIssue, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("could not read JS config %s: %w", path, err)
+	}
+
+	env := newDSLEnv(filepath.Dir(path), path)
+	if _, err := env.vm.RunScript(path, string(src)); err != nil {
+		return nil, 0, nil, fmt.Errorf("could not evaluate JS config %s: %w", path, err)
+	}
+
+	return env.rules, env.count, env.issues, nil
+}
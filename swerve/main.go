@@ -19,22 +19,33 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/jessevdk/go-flags"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// globalOpts is populated by go-flags during Parse() and is also how the
+// validate subcommand (see validate.go), which go-flags invokes as part of
+// that same Parse() call, gets at the options that apply to it (AWS
+// credentials, merge mode, ...).
+var globalOpts Options
+
 // Options holds the application's configuration.
 type Options struct {
-	CsvSrc             string        `long:"csv-src" env:"SWERVE_CSV_SRC" description:"Source for the CSV redirect files. Can be a local path or an S3 URI." default:"/app/redirects"`
-	PollInterval       time.Duration `long:"poll-interval" env:"SWERVE_POLL_INTERVAL" description:"Interval to poll for rule changes (e.g., 5m, 1h). Set to 0 to disable." default:"0"`
-	HealthCheckDomain  string        `long:"health-check-domain" env:"SWERVE_HEALTH_CHECK_DOMAIN" description:"The domain on which to expose the health check endpoint. If empty, it responds on all domains."`
-	HealthCheckPath    string        `long:"health-check-path" env:"SWERVE_HEALTH_CHECK_PATH" description:"The path for the health check endpoint (e.g., /healthz). If not set, the endpoint is disabled."`
-	AWSRegion          string        `long:"aws-region" env:"AWS_REGION" description:"The AWS region for the S3 bucket."`
-	AWSAccessKeyID     string        `long:"aws-access-key-id" env:"AWS_ACCESS_KEY_ID" description:"AWS access key. If not set, IAM role is assumed."`
-	AWSSecretAccessKey string        `long:"aws-secret-access-key" env:"AWS_SECRET_ACCESS_KEY" description:"AWS secret key."`
-	AWSSessionToken    string        `long:"aws-session-token" env:"AWS_SESSION_TOKEN" description:"AWS session token."`
+	CsvSrc              []string      `long:"csv-src" env:"SWERVE_CSV_SRC" description:"Source for the redirect rule files (.csv or .js). Can be a local path, an S3 URI, an http(s):// URL, or a git repo. Repeatable; sources are loaded in parallel and combined per --merge-mode." default:"/app/redirects"`
+	MergeMode           string        `long:"merge-mode" env:"SWERVE_MERGE_MODE" description:"How to combine rules from multiple --csv-src values that define the same host." default:"append" choice:"append" choice:"override" choice:"error-on-conflict"`
+	PollInterval        time.Duration `long:"poll-interval" env:"SWERVE_POLL_INTERVAL" description:"Interval to poll for rule changes (e.g., 5m, 1h). Set to 0 to disable." default:"0"`
+	DryRun              bool          `long:"dry-run" env:"SWERVE_DRY_RUN" description:"Validate every --csv-src (like 'swerve validate') and exit non-zero on any error, without binding :8080."`
+	HealthCheckDomain   string        `long:"health-check-domain" env:"SWERVE_HEALTH_CHECK_DOMAIN" description:"The domain on which to expose the health check endpoint. If empty, it responds on all domains."`
+	HealthCheckPath     string        `long:"health-check-path" env:"SWERVE_HEALTH_CHECK_PATH" description:"The path for the health check endpoint (e.g., /healthz). If not set, the endpoint is disabled."`
+	MetricsPath         string        `long:"metrics-path" env:"SWERVE_METRICS_PATH" description:"The path for the Prometheus metrics endpoint. Set to an empty string to disable the endpoint." default:"/metrics"`
+	AWSRegion           string        `long:"aws-region" env:"AWS_REGION" description:"The AWS region for the S3 bucket."`
+	AWSAccessKeyID      string        `long:"aws-access-key-id" env:"AWS_ACCESS_KEY_ID" description:"AWS access key. If not set, IAM role is assumed."`
+	AWSSecretAccessKey  string        `long:"aws-secret-access-key" env:"AWS_SECRET_ACCESS_KEY" description:"AWS secret key."`
+	AWSSessionToken     string        `long:"aws-session-token" env:"AWS_SESSION_TOKEN" description:"AWS session token."`
+	AWSAnonymous        bool          `long:"aws-anonymous" env:"SWERVE_AWS_ANONYMOUS" description:"Use anonymous (unsigned) requests, for reading a public S3 bucket. Takes precedence over static credentials and --aws-profile."`
+	AWSProfile          string        `long:"aws-profile" env:"AWS_PROFILE" description:"Named AWS shared-config profile for the default credential chain to use. Ignored when static credentials or --aws-anonymous are set."`
+	S3NotificationQueue string        `long:"s3-notification-queue" env:"SWERVE_S3_NOTIFICATION_QUEUE" description:"URL of an SQS queue subscribed to s3:ObjectCreated:*/s3:ObjectRemoved:* events on the CsvSrc bucket/prefix. When set, rules are reloaded incrementally as events arrive instead of (or alongside) polling."`
 }
 
 // Redirect represents a single, compiled redirect rule.
@@ -46,6 +57,7 @@ type Redirect struct {
 	StatusCode        int
 	Weight            int
 	Regex             *regexp.Regexp // Holds the compiled regular expression
+	Source            string         // File path or S3 key this rule was loaded from
 }
 
 // HealthStatus represents the JSON response for the health check.
@@ -72,23 +84,40 @@ var (
 	mapMutex    = &sync.RWMutex{}
 )
 
+// LoadIssue is a non-fatal problem found while loading rules, such as a
+// skipped row or an invalid regex. Normal loads log these as warnings;
+// `swerve validate` collects and reports them instead.
+type LoadIssue struct {
+	Source  string
+	Message string
+}
+
+// logIssue logs a load-time warning and returns it as a LoadIssue so callers
+// that want a structured report (validate) can also collect it.
+func logIssue(source, format string, args ...interface{}) LoadIssue {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(msg)
+	return LoadIssue{Source: source, Message: msg}
+}
+
 // parseRules reads CSV data from an io.Reader and converts it into a map of Redirect rules.
-func parseRules(csvData io.Reader, sourceName string) (map[string][]Redirect, int, error) {
+func parseRules(csvData io.Reader, sourceName string) (map[string][]Redirect, int, []LoadIssue, error) {
 	reader := csv.NewReader(csvData)
 	reader.FieldsPerRecord = -1 // Allow variable number of fields
 	records, err := reader.ReadAll()
 	if err != nil {
-		return nil, 0, fmt.Errorf("could not parse CSV from %s: %w", sourceName, err)
+		return nil, 0, nil, fmt.Errorf("could not parse CSV from %s: %w", sourceName, err)
 	}
 
 	rulesByHost := make(map[string][]Redirect)
 	rulesCount := 0
+	var issues []LoadIssue
 	for i, record := range records[1:] { // Skip header row
 		if len(record) == 0 || (len(record) == 1 && record[0] == "") || strings.HasPrefix(strings.TrimSpace(record[0]), "#") {
 			continue
 		}
 		if len(record) != 6 {
-			log.Printf("File %s, Line %d: Skipping invalid record (must have 6 columns): %v", sourceName, i+2, record)
+			issues = append(issues, logIssue(sourceName, "File %s, Line %d: Skipping invalid record (must have 6 columns): %v", sourceName, i+2, record))
 			continue
 		}
 
@@ -103,31 +132,42 @@ func parseRules(csvData io.Reader, sourceName string) (map[string][]Redirect, in
 			TargetURLFormat:   strings.TrimSpace(record[3]),
 			StatusCode:        statusCode,
 			Weight:            weight,
+			Source:            sourceName,
 		}
 
 		if matchType == "regex" {
 			rule.Regex, err = regexp.Compile(pathOrRegex)
 			if err != nil {
-				log.Printf("File %s, Line %d: Invalid regex '%s', skipping rule. Error: %v", sourceName, i+2, pathOrRegex, err)
+				issues = append(issues, logIssue(sourceName, "File %s, Line %d: Invalid regex '%s', skipping rule. Error: %v", sourceName, i+2, pathOrRegex, err))
 				continue
 			}
 		}
 		rulesByHost[host] = append(rulesByHost[host], rule)
 		rulesCount++
 	}
-	return rulesByHost, rulesCount, nil
+	return rulesByHost, rulesCount, issues, nil
 }
 
-// loadRedirectsFromDir loads all .csv files from a local directory.
-func loadRedirectsFromDir(dirPath string) (map[string][]Redirect, int, error) {
+// loadRedirectsFromDir loads all .csv and .js rule files from a local
+// directory, picking the loader for each file by its extension.
+func loadRedirectsFromDir(dirPath string) (map[string][]Redirect, int, []LoadIssue, error) {
 	aggregatedRules := make(map[string][]Redirect)
 	totalRules := 0
+	var allIssues []LoadIssue
 
 	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !d.IsDir() && strings.HasSuffix(strings.ToLower(d.Name()), ".csv") {
+		if d.IsDir() {
+			return nil
+		}
+
+		var rules map[string][]Redirect
+		var count int
+		var issues []LoadIssue
+		switch strings.ToLower(filepath.Ext(d.Name())) {
+		case ".csv":
 			log.Printf("Processing file: %s", path)
 			file, err := os.Open(path)
 			if err != nil {
@@ -136,53 +176,59 @@ func loadRedirectsFromDir(dirPath string) (map[string][]Redirect, int, error) {
 			}
 			defer file.Close()
 
-			rules, count, err := parseRules(file, path)
+			rules, count, issues, err = parseRules(file, path)
 			if err != nil {
 				log.Printf("WARNING: %v", err)
 				return nil
 			}
-			for host, hostRules := range rules {
-				aggregatedRules[host] = append(aggregatedRules[host], hostRules...)
+		case ".js":
+			log.Printf("Processing file: %s", path)
+			rules, count, issues, err = loadRedirectsFromJS(path)
+			if err != nil {
+				log.Printf("WARNING: %v", err)
+				return nil
 			}
-			totalRules += count
+		default:
+			return nil
+		}
+
+		for host, hostRules := range rules {
+			aggregatedRules[host] = append(aggregatedRules[host], hostRules...)
 		}
+		totalRules += count
+		allIssues = append(allIssues, issues...)
 		return nil
 	})
 
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
-	return aggregatedRules, totalRules, nil
+	return aggregatedRules, totalRules, allIssues, nil
 }
 
-// loadRedirectsFromS3 loads all .csv files from an S3 bucket/prefix.
-func loadRedirectsFromS3(s3Path string, opts Options) (map[string][]Redirect, int, error) {
+// splitS3Path splits an "s3://bucket/prefix" URI into its bucket and prefix.
+func splitS3Path(s3Path string) (bucket, prefix string) {
 	pathParts := strings.SplitN(strings.TrimPrefix(s3Path, "s3://"), "/", 2)
-	bucket := pathParts[0]
-	prefix := ""
+	bucket = pathParts[0]
 	if len(pathParts) > 1 {
 		prefix = pathParts[1]
 	}
+	return bucket, prefix
+}
 
-	var cfgOptions []func(*config.LoadOptions) error
-	if opts.AWSAccessKeyID != "" && opts.AWSSecretAccessKey != "" {
-		log.Println("Using static AWS credentials.")
-		creds := credentials.NewStaticCredentialsProvider(opts.AWSAccessKeyID, opts.AWSSecretAccessKey, opts.AWSSessionToken)
-		cfgOptions = append(cfgOptions, config.WithCredentialsProvider(creds))
-	} else {
-		log.Println("Using default AWS credential chain (e.g., IAM role).")
-	}
-
-	if opts.AWSRegion != "" {
-		cfgOptions = append(cfgOptions, config.WithRegion(opts.AWSRegion))
-	}
+// loadRedirectsFromS3 loads all .csv files from an S3 bucket/prefix. It also
+// (re)seeds s3KeyHosts and s3ObjectETags from this full listing, so that a
+// later incremental reload via mergeS3ObjectRules/removeKeyFromMapLocked
+// knows which hosts a key already loaded here owns, instead of treating
+// every key as new and appending duplicate rules on top of them.
+func loadRedirectsFromS3(s3Path string, opts Options) (map[string][]Redirect, int, []LoadIssue, error) {
+	bucket, prefix := splitS3Path(s3Path)
 
-	cfg, err := config.LoadDefaultConfig(context.TODO(), cfgOptions...)
+	client, err := newS3Client(context.TODO(), opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to load AWS configuration: %w", err)
+		return nil, 0, nil, err
 	}
 
-	client := s3.NewFromConfig(cfg)
 	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
 		Bucket: aws.String(bucket),
 		Prefix: aws.String(prefix),
@@ -190,11 +236,14 @@ func loadRedirectsFromS3(s3Path string, opts Options) (map[string][]Redirect, in
 
 	aggregatedRules := make(map[string][]Redirect)
 	totalRules := 0
+	var allIssues []LoadIssue
+	keyHosts := make(map[s3KeyID][]string)
+	keyETags := make(map[s3KeyID]string)
 
 	for paginator.HasMorePages() {
 		page, err := paginator.NextPage(context.TODO())
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to list objects in S3 bucket %s: %w", bucket, err)
+			return nil, 0, nil, fmt.Errorf("failed to list objects in S3 bucket %s: %w", bucket, err)
 		}
 		for _, obj := range page.Contents {
 			if !strings.HasSuffix(strings.ToLower(*obj.Key), ".csv") {
@@ -207,66 +256,139 @@ func loadRedirectsFromS3(s3Path string, opts Options) (map[string][]Redirect, in
 				continue
 			}
 
-			rules, count, err := parseRules(resp.Body, *obj.Key)
+			rules, count, issues, err := parseRules(resp.Body, *obj.Key)
 			resp.Body.Close()
 			if err != nil {
 				log.Printf("WARNING: %v", err)
 				continue
 			}
+			hosts := make([]string, 0, len(rules))
 			for host, hostRules := range rules {
 				aggregatedRules[host] = append(aggregatedRules[host], hostRules...)
+				hosts = append(hosts, host)
 			}
+			id := s3KeyID{source: s3Path, key: *obj.Key}
+			keyHosts[id] = hosts
+			keyETags[id] = strings.Trim(aws.ToString(obj.ETag), `"`)
 			totalRules += count
+			allIssues = append(allIssues, issues...)
 		}
 	}
-	return aggregatedRules, totalRules, nil
+
+	// Replace only this source's own entries, so a second s3:// --csv-src
+	// doesn't clobber the index another one already populated.
+	s3KeyHostsMu.Lock()
+	for id := range s3KeyHosts {
+		if id.source == s3Path {
+			delete(s3KeyHosts, id)
+		}
+	}
+	for id, hosts := range keyHosts {
+		s3KeyHosts[id] = hosts
+	}
+	s3KeyHostsMu.Unlock()
+
+	s3ObjectETagsMu.Lock()
+	for id := range s3ObjectETags {
+		if id.source == s3Path {
+			delete(s3ObjectETags, id)
+		}
+	}
+	for id, etag := range keyETags {
+		s3ObjectETags[id] = etag
+	}
+	s3ObjectETagsMu.Unlock()
+
+	// Seed s3SourceKeys too, so an incremental override merge (chunk0-2)
+	// knows which keys belong to this source even before any SQS event or
+	// reconciliation sweep has touched it.
+	keys := make([]string, 0, len(keyHosts))
+	for id := range keyHosts {
+		keys = append(keys, id.key)
+	}
+	resetS3SourceKeys(s3Path, keys)
+
+	return aggregatedRules, totalRules, allIssues, nil
 }
 
-// loadRules orchestrates loading rules from the configured source.
-func loadRules(opts Options) error {
-	var tempRedirects map[string][]Redirect
-	var totalRules int
-	var err error
-
-	if strings.HasPrefix(opts.CsvSrc, "s3://") {
-		log.Printf("Loading redirects from S3 source: %s", opts.CsvSrc)
-		tempRedirects, totalRules, err = loadRedirectsFromS3(opts.CsvSrc, opts)
-	} else {
-		log.Printf("Loading redirects from local directory: %s", opts.CsvSrc)
-		tempRedirects, totalRules, err = loadRedirectsFromDir(opts.CsvSrc)
+// loadRules loads rules from every source in sources in parallel and merges
+// them per opts.MergeMode. sources must be the same, long-lived RuleSource
+// instances across calls (built once in main via resolveSources) so that
+// per-source state - gitSource's clone dir, httpSource's ETag cache - is
+// actually reused between reloads instead of starting over every time.
+func loadRules(sources []RuleSource, opts Options) error {
+	type loadResult struct {
+		rules map[string][]Redirect
+		count int
+		err   error
 	}
 
-	if err != nil {
-		return err
+	results := make([]loadResult, len(sources))
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src RuleSource) {
+			defer wg.Done()
+			log.Printf("Loading redirects from source: %s", src)
+			rules, count, _, err := src.Load(context.Background())
+			results[i] = loadResult{rules: rules, count: count, err: err}
+		}(i, src)
 	}
+	wg.Wait()
 
-	for host := range tempRedirects {
-		sort.Slice(tempRedirects[host], func(i, j int) bool {
-			return tempRedirects[host][i].Weight > tempRedirects[host][j].Weight
+	merged := make(map[string][]Redirect)
+	totalRules := 0
+	for i, res := range results {
+		if res.err != nil {
+			ruleReloadTotal.WithLabelValues("error").Inc()
+			return fmt.Errorf("source %s: %w", sources[i], res.err)
+		}
+		if err := mergeSourceRules(merged, res.rules, opts.MergeMode); err != nil {
+			ruleReloadTotal.WithLabelValues("error").Inc()
+			return err
+		}
+		totalRules += res.count
+	}
+
+	for host := range merged {
+		sort.Slice(merged[host], func(i, j int) bool {
+			return merged[host][i].Weight > merged[host][j].Weight
 		})
 	}
 
 	mapMutex.Lock()
-	redirectMap = tempRedirects
+	redirectMap = merged
 	mapMutex.Unlock()
 
-	log.Printf("Successfully loaded %d redirect rules across %d domains.", totalRules, len(redirectMap))
+	recordRulesLoadedMetric(merged)
+	ruleReloadTotal.WithLabelValues("success").Inc()
+
+	log.Printf("Successfully loaded %d redirect rules across %d domains from %d source(s).", totalRules, len(merged), len(sources))
 	return nil
 }
 
 // redirectHandler finds the highest-weighted matching rule and performs the redirect.
 func redirectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "swerve.redirect")
+	defer span.End()
+
 	host := r.Host
 	if i := strings.LastIndex(host, ":"); i != -1 {
 		host = host[:i]
 	}
 	path := r.URL.Path
+	span.SetAttributes(attribute.String("host", host))
 
 	mapMutex.RLock()
 	rules, hostExists := redirectMap[host]
 	mapMutex.RUnlock()
 
 	if !hostExists {
+		// host comes straight from the (attacker-controlled) Host header;
+		// only label swerve_requests_total with hosts we actually serve,
+		// or a client sending random Host headers could grow the host
+		// label without bound.
+		recordRequestMetric(unknownHostLabel, http.StatusNotFound)
 		http.NotFound(w, r)
 		return
 	}
@@ -276,6 +398,7 @@ func redirectHandler(w http.ResponseWriter, r *http.Request) {
 		normalizedPath = strings.TrimRight(path, "/")
 	}
 
+	matchStart := time.Now()
 	for _, rule := range rules {
 		targetURL := ""
 
@@ -293,6 +416,13 @@ func redirectHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if targetURL != "" {
+			ruleMatchDuration.Observe(time.Since(matchStart).Seconds())
+			span.SetAttributes(
+				attribute.String("rule", rule.SourcePathOrRegex),
+				attribute.Int("weight", rule.Weight),
+				attribute.Int("status_code", rule.StatusCode),
+			)
+
 			// *** UPDATED: Log successful redirects as structured JSON ***
 			logEntry := RedirectLogEntry{
 				Timestamp: time.Now().UTC().Format(time.RFC3339),
@@ -312,12 +442,16 @@ func redirectHandler(w http.ResponseWriter, r *http.Request) {
 				log.Println(string(logJSON))
 			}
 
-			http.Redirect(w, r, targetURL, rule.StatusCode)
+			recordRequestMetric(host, rule.StatusCode)
+			redirectsTotal.WithLabelValues(host, rule.SourcePathOrRegex, strconv.Itoa(rule.StatusCode)).Inc()
+			http.Redirect(w, r.WithContext(ctx), targetURL, rule.StatusCode)
 			return
 		}
 	}
+	ruleMatchDuration.Observe(time.Since(matchStart).Seconds())
 
 	log.Printf("No match found for: %s%s", host, path)
+	recordRequestMetric(host, http.StatusNotFound)
 	http.NotFound(w, r)
 }
 
@@ -343,8 +477,11 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
-	var opts Options
-	parser := flags.NewParser(&opts, flags.Default)
+	parser := flags.NewParser(&globalOpts, flags.Default)
+	if _, err := parser.AddCommand("validate", "Validate a rule source", "Runs the same loading pipeline as the server against a single source and prints a structured report, without starting it.", &ValidateCommand{}); err != nil {
+		log.Fatalf("FATAL: could not register validate command: %v", err)
+	}
+
 	if _, err := parser.Parse(); err != nil {
 		if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 			os.Exit(0)
@@ -353,7 +490,34 @@ func main() {
 		}
 	}
 
-	if err := loadRules(opts); err != nil {
+	if parser.Active != nil {
+		// A subcommand (validate) ran during Parse(); nothing more to do.
+		return
+	}
+
+	opts := globalOpts
+	if len(opts.CsvSrc) == 0 {
+		opts.CsvSrc = []string{"/app/redirects"}
+	}
+
+	if opts.DryRun {
+		exitCode := 0
+		for _, src := range opts.CsvSrc {
+			report := validateSource(context.Background(), src, opts)
+			printValidationReport(report)
+			if len(report.Errors) > 0 {
+				exitCode = 1
+			}
+		}
+		os.Exit(exitCode)
+	}
+
+	// Resolved once and reused for every load below, so per-source state
+	// (gitSource's clone dir, httpSource's ETag cache) persists across
+	// reloads instead of every poll/event starting from scratch.
+	sources := resolveSources(opts.CsvSrc, opts)
+
+	if err := loadRules(sources, opts); err != nil {
 		log.Fatalf("FATAL: Failed to perform initial load of redirect rules: %v", err)
 	}
 
@@ -362,15 +526,48 @@ func main() {
 			ticker := time.NewTicker(opts.PollInterval)
 			for range ticker.C {
 				log.Println("Polling for rule updates...")
-				if err := loadRules(opts); err != nil {
+				if err := loadRules(sources, opts); err != nil {
 					log.Printf("ERROR: Failed to reload rules: %v", err)
 				}
 			}
 		}()
 	}
 
+	// Every s3Source.Watch would long-poll the same queue independently, so
+	// an SQS message could be popped by a watcher for the wrong bucket and
+	// indexed (and later retracted) under the wrong source. Rather than
+	// scope watchers by the event's bucket, only support the queue with a
+	// single s3:// source until that's needed.
+	if opts.S3NotificationQueue != "" {
+		switch n := countS3Sources(opts.CsvSrc); {
+		case n == 0:
+			log.Fatalf("FATAL: --s3-notification-queue requires at least one --csv-src to be an s3:// URI")
+		case n > 1:
+			log.Fatalf("FATAL: --s3-notification-queue does not support more than one s3:// --csv-src")
+		}
+	}
+
+	// Give every source a chance to push change notifications (currently
+	// only s3Source, via --s3-notification-queue); sources without a push
+	// mechanism return immediately and rely on --poll-interval instead.
+	changes := make(chan Event, 16)
+	for _, src := range sources {
+		go src.Watch(context.Background(), changes)
+	}
+	go func() {
+		for ev := range changes {
+			log.Printf("Change notification from source %s", ev.Source)
+		}
+	}()
+
 	// Create a single handler that routes requests.
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Check if the request is for the metrics endpoint.
+		if opts.MetricsPath != "" && r.URL.Path == opts.MetricsPath {
+			metricsHandler.ServeHTTP(w, r)
+			return
+		}
+
 		// Check if the request is for the health check endpoint.
 		if opts.HealthCheckPath != "" && r.URL.Path == opts.HealthCheckPath {
 			// If a health check domain is specified, it must match.
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// stubS3Client is a hand-rolled s3API for tests, serving a fixed set of
+// objects without any network access.
+type stubS3Client struct {
+	objects map[string]string // key -> CSV body
+	etags   map[string]string // key -> ETag
+}
+
+func (c *stubS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	out := &s3.ListObjectsV2Output{}
+	for key := range c.objects {
+		out.Contents = append(out.Contents, types.Object{
+			Key:  aws.String(key),
+			ETag: aws.String(c.etags[key]),
+		})
+	}
+	return out, nil
+}
+
+func (c *stubS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	key := aws.ToString(params.Key)
+	body, ok := c.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("stubS3Client: no such key %q", key)
+	}
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(strings.NewReader(body)),
+		ETag: aws.String(c.etags[key]),
+	}, nil
+}
+
+func TestLoadRedirectsFromS3_SeedsKeyIndexes(t *testing.T) {
+	stub := &stubS3Client{
+		objects: map[string]string{
+			"rules.csv": "host,type,source,target,status,weight\nexample.com,exact,/a,/b,301,10\n",
+		},
+		etags: map[string]string{"rules.csv": `"abc123"`},
+	}
+
+	orig := newS3Client
+	newS3Client = func(ctx context.Context, opts Options) (s3API, error) { return stub, nil }
+	defer func() { newS3Client = orig }()
+
+	rules, count, _, err := loadRedirectsFromS3("s3://bucket/prefix", Options{})
+	if err != nil {
+		t.Fatalf("loadRedirectsFromS3: %v", err)
+	}
+	if count != 1 || len(rules["example.com"]) != 1 {
+		t.Fatalf("expected 1 rule for example.com, got %+v (count=%d)", rules, count)
+	}
+
+	id := s3KeyID{source: "s3://bucket/prefix", key: "rules.csv"}
+
+	s3KeyHostsMu.Lock()
+	hosts := s3KeyHosts[id]
+	s3KeyHostsMu.Unlock()
+	if len(hosts) != 1 || hosts[0] != "example.com" {
+		t.Fatalf("expected s3KeyHosts[%v] = [example.com], got %v", id, hosts)
+	}
+
+	s3ObjectETagsMu.Lock()
+	etag := s3ObjectETags[id]
+	s3ObjectETagsMu.Unlock()
+	if etag != "abc123" {
+		t.Fatalf("expected seeded etag abc123, got %q", etag)
+	}
+}
+
+func TestLoadRedirectsFromS3_ScopesKeyIndexPerSource(t *testing.T) {
+	stubA := &stubS3Client{
+		objects: map[string]string{"rules.csv": "host,type,source,target,status,weight\na.example.com,exact,/a,/b,301,10\n"},
+		etags:   map[string]string{"rules.csv": `"aaa"`},
+	}
+	stubB := &stubS3Client{
+		objects: map[string]string{"rules.csv": "host,type,source,target,status,weight\nb.example.com,exact,/a,/b,301,10\n"},
+		etags:   map[string]string{"rules.csv": `"bbb"`},
+	}
+
+	orig := newS3Client
+	defer func() { newS3Client = orig }()
+
+	newS3Client = func(ctx context.Context, opts Options) (s3API, error) { return stubA, nil }
+	if _, _, _, err := loadRedirectsFromS3("s3://bucket-a/prefix", Options{}); err != nil {
+		t.Fatalf("loadRedirectsFromS3(a): %v", err)
+	}
+
+	newS3Client = func(ctx context.Context, opts Options) (s3API, error) { return stubB, nil }
+	if _, _, _, err := loadRedirectsFromS3("s3://bucket-b/prefix", Options{}); err != nil {
+		t.Fatalf("loadRedirectsFromS3(b): %v", err)
+	}
+
+	idA := s3KeyID{source: "s3://bucket-a/prefix", key: "rules.csv"}
+	idB := s3KeyID{source: "s3://bucket-b/prefix", key: "rules.csv"}
+
+	s3KeyHostsMu.Lock()
+	hostsA, hostsB := s3KeyHosts[idA], s3KeyHosts[idB]
+	s3KeyHostsMu.Unlock()
+	if len(hostsA) != 1 || hostsA[0] != "a.example.com" {
+		t.Fatalf("expected source a's index to survive loading source b, got %v", hostsA)
+	}
+	if len(hostsB) != 1 || hostsB[0] != "b.example.com" {
+		t.Fatalf("expected source b's index to be populated, got %v", hostsB)
+	}
+}
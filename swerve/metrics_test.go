@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordRequestMetric_KnownHost(t *testing.T) {
+	requestsTotal.Reset()
+
+	recordRequestMetric("example.com", http.StatusFound)
+
+	got := testutil.ToFloat64(requestsTotal.WithLabelValues("example.com", http.StatusText(http.StatusFound)))
+	if got != 1 {
+		t.Fatalf("expected 1 request recorded for example.com, got %v", got)
+	}
+}
+
+func TestRecordRequestMetric_UnknownHostsDontLeakLabels(t *testing.T) {
+	requestsTotal.Reset()
+
+	// None of these hosts is in redirectMap; redirectHandler must record them
+	// under unknownHostLabel rather than the raw, attacker-controlled Host.
+	for _, host := range []string{"a.evil.example", "b.evil.example", "c.evil.example"} {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		redirectHandler(httptest.NewRecorder(), req)
+	}
+
+	got := testutil.ToFloat64(requestsTotal.WithLabelValues(unknownHostLabel, http.StatusText(http.StatusNotFound)))
+	if got != 3 {
+		t.Fatalf("expected 3 requests bucketed under %q, got %v", unknownHostLabel, got)
+	}
+	if count := testutil.CollectAndCount(requestsTotal); count != 1 {
+		t.Fatalf("expected a single time series for repeated unknown hosts, got %d", count)
+	}
+}
+
+func TestRecordRulesLoadedMetric(t *testing.T) {
+	rulesLoaded.Reset()
+
+	recordRulesLoadedMetric(map[string][]Redirect{
+		"example.com": {{}, {}},
+		"other.com":   {{}},
+	})
+
+	if got := testutil.ToFloat64(rulesLoaded.WithLabelValues("example.com")); got != 2 {
+		t.Fatalf("expected 2 rules loaded for example.com, got %v", got)
+	}
+	if got := testutil.ToFloat64(rulesLoaded.WithLabelValues("other.com")); got != 1 {
+		t.Fatalf("expected 1 rule loaded for other.com, got %v", got)
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// s3ReconcileInterval is how often watchS3Notifications re-lists the bucket
+// and compares ETags, in case an SQS message was missed or the queue was
+// briefly unavailable.
+const s3ReconcileInterval = 10 * time.Minute
+
+// s3KeyID identifies an S3 object scoped to the --csv-src it was loaded
+// from, so s3KeyHosts/s3ObjectETags don't collide when more than one
+// --csv-src is an s3:// source (object keys are only unique within their
+// own bucket/prefix).
+type s3KeyID struct {
+	source string // the s3:// --csv-src (e.g. "s3://bucket/prefix") this key belongs to
+	key    string // the S3 object key
+}
+
+var (
+	// s3KeyHosts tracks which hosts each S3 key most recently contributed
+	// rules to, so a later update or delete for that key only touches the
+	// rules it owns rather than the whole host.
+	s3KeyHosts   = make(map[s3KeyID][]string)
+	s3KeyHostsMu sync.Mutex
+
+	// s3ObjectETags tracks the last-loaded ETag per key for the
+	// reconciliation sweep.
+	s3ObjectETags   = make(map[s3KeyID]string)
+	s3ObjectETagsMu sync.Mutex
+
+	// s3SourceKeys tracks which object keys each s3Src has contributed, so
+	// an incremental "override" merge can tell its own source's sibling
+	// keys (kept) apart from other --csv-src sources' rules (dropped) when
+	// replacing a host's rules - matching the source-level granularity a
+	// full load applies in mergeSourceRules.
+	s3SourceKeys   = make(map[string]map[string]struct{})
+	s3SourceKeysMu sync.Mutex
+)
+
+// rememberS3SourceKey records that key was (re)loaded from s3Src.
+func rememberS3SourceKey(s3Src, key string) {
+	s3SourceKeysMu.Lock()
+	defer s3SourceKeysMu.Unlock()
+	keys := s3SourceKeys[s3Src]
+	if keys == nil {
+		keys = make(map[string]struct{})
+		s3SourceKeys[s3Src] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// forgetS3SourceKey undoes rememberS3SourceKey for a deleted key.
+func forgetS3SourceKey(s3Src, key string) {
+	s3SourceKeysMu.Lock()
+	defer s3SourceKeysMu.Unlock()
+	delete(s3SourceKeys[s3Src], key)
+}
+
+// resetS3SourceKeys replaces s3Src's full set of known object keys, e.g.
+// after a full load re-lists the bucket from scratch. Like s3KeyHosts and
+// s3ObjectETags, it only replaces this source's own entries.
+func resetS3SourceKeys(s3Src string, keys []string) {
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+
+	s3SourceKeysMu.Lock()
+	defer s3SourceKeysMu.Unlock()
+	s3SourceKeys[s3Src] = set
+}
+
+// ownedByS3Source reports whether source - a Redirect.Source value - is a
+// key that was loaded from s3Src, as opposed to some other --csv-src.
+func ownedByS3Source(source, s3Src string) bool {
+	s3SourceKeysMu.Lock()
+	defer s3SourceKeysMu.Unlock()
+	_, ok := s3SourceKeys[s3Src][source]
+	return ok
+}
+
+// s3EventNotification is the subset of the S3 event notification JSON
+// (https://docs.aws.amazon.com/AmazonS3/latest/userguide/notification-content-structure.html)
+// that watchS3Notifications needs.
+type s3EventNotification struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// watchS3Notifications long-polls queueURL for S3 bucket notifications and
+// incrementally reloads the object each one names, merging the result into
+// redirectMap under mapMutex instead of reloading everything. s3Src is the
+// "s3://bucket/prefix" source the reconciliation sweep re-lists. changes
+// receives an Event per merge/retraction, for the RuleSource.Watch caller;
+// it may be nil. It runs until ctx is cancelled.
+func watchS3Notifications(ctx context.Context, opts Options, queueURL, s3Src string, changes chan<- Event) {
+	cfg, err := loadAWSConfig(ctx, opts)
+	if err != nil {
+		log.Printf("ERROR: could not configure SQS client for %s: %v", queueURL, err)
+		return
+	}
+	client := sqs.NewFromConfig(cfg)
+
+	go runS3ReconcileLoop(ctx, opts, s3Src, changes)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(queueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("ERROR: SQS ReceiveMessage failed: %v", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		for _, msg := range out.Messages {
+			handleS3NotificationMessage(ctx, opts, aws.ToString(msg.Body), s3Src, changes)
+			if _, err := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(queueURL),
+				ReceiptHandle: msg.ReceiptHandle,
+			}); err != nil {
+				log.Printf("WARNING: could not delete SQS message: %v", err)
+			}
+		}
+	}
+}
+
+func handleS3NotificationMessage(ctx context.Context, opts Options, body, s3Src string, changes chan<- Event) {
+	var event s3EventNotification
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		log.Printf("WARNING: could not parse S3 event notification: %v", err)
+		return
+	}
+
+	for _, record := range event.Records {
+		switch {
+		case strings.HasPrefix(record.EventName, "ObjectRemoved"):
+			removeS3ObjectRules(record.S3.Object.Key, s3Src)
+			notifyChange(changes, s3Src)
+		case strings.HasPrefix(record.EventName, "ObjectCreated"):
+			if err := reloadS3Object(ctx, opts, record.S3.Bucket.Name, record.S3.Object.Key, s3Src); err != nil {
+				log.Printf("WARNING: could not reload s3://%s/%s: %v", record.S3.Bucket.Name, record.S3.Object.Key, err)
+				break
+			}
+			notifyChange(changes, s3Src)
+		}
+	}
+}
+
+// reloadS3Object fetches a single S3 key and merges the rules it contains
+// into redirectMap. Only .csv keys are handled, matching loadRedirectsFromS3.
+// s3Src is the --csv-src this key belongs to, for scoping s3KeyHosts and
+// s3ObjectETags.
+func reloadS3Object(ctx context.Context, opts Options, bucket, key, s3Src string) error {
+	if !strings.HasSuffix(strings.ToLower(key), ".csv") {
+		return nil
+	}
+
+	client, err := newS3Client(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return fmt.Errorf("could not get object: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rules, count, _, err := parseRules(resp.Body, key)
+	if err != nil {
+		return err
+	}
+
+	if err := mergeS3ObjectRules(key, s3Src, rules, opts.MergeMode); err != nil {
+		return err
+	}
+
+	id := s3KeyID{source: s3Src, key: key}
+	s3ObjectETagsMu.Lock()
+	s3ObjectETags[id] = strings.Trim(aws.ToString(resp.ETag), `"`)
+	s3ObjectETagsMu.Unlock()
+
+	log.Printf("Incrementally reloaded %d rule(s) across %d host(s) from s3://%s/%s", count, len(rules), bucket, key)
+	return nil
+}
+
+// mergeS3ObjectRules merges the rules key currently contributes into
+// redirectMap per mergeMode, replacing whatever this key previously
+// contributed, then re-sorts the affected hosts by weight. "append" (the
+// default) concatenates with whatever else is on the host, matching
+// mergeSourceRules; "override" replaces the rules other --csv-src sources
+// contributed to the host, but keeps this s3Src's other keys - the same
+// source-level granularity a full load applies in mergeSourceRules, since a
+// full load aggregates every object within one S3 source before override is
+// applied across sources; "error-on-conflict" refuses to merge into a host
+// another --csv-src already defined, but - again matching mergeSourceRules -
+// allows this s3Src's own sibling keys to share a host.
+func mergeS3ObjectRules(key, s3Src string, rules map[string][]Redirect, mergeMode string) error {
+	id := s3KeyID{source: s3Src, key: key}
+
+	newHosts := make([]string, 0, len(rules))
+	for host := range rules {
+		newHosts = append(newHosts, host)
+	}
+
+	mapMutex.Lock()
+	defer mapMutex.Unlock()
+
+	if mergeMode == "error-on-conflict" {
+		for host := range rules {
+			for _, r := range redirectMap[host] {
+				if r.Source != key && !ownedByS3Source(r.Source, s3Src) {
+					ruleReloadTotal.WithLabelValues("error").Inc()
+					return fmt.Errorf("merge conflict: host %q is already defined by another source (merge-mode=error-on-conflict)", host)
+				}
+			}
+		}
+	}
+
+	removeKeyFromMapLocked(key, s3Src)
+	for host, hostRules := range rules {
+		if mergeMode == "override" {
+			kept := make([]Redirect, 0, len(redirectMap[host]))
+			for _, r := range redirectMap[host] {
+				if ownedByS3Source(r.Source, s3Src) {
+					kept = append(kept, r)
+				}
+			}
+			redirectMap[host] = append(kept, hostRules...)
+		} else {
+			redirectMap[host] = append(redirectMap[host], hostRules...)
+		}
+		sort.Slice(redirectMap[host], func(i, j int) bool {
+			return redirectMap[host][i].Weight > redirectMap[host][j].Weight
+		})
+	}
+
+	s3KeyHostsMu.Lock()
+	s3KeyHosts[id] = newHosts
+	s3KeyHostsMu.Unlock()
+	rememberS3SourceKey(s3Src, key)
+
+	recordRulesLoadedMetric(redirectMap)
+	ruleReloadTotal.WithLabelValues("success").Inc()
+
+	return nil
+}
+
+// removeS3ObjectRules retracts the rules a deleted S3 key contributed.
+func removeS3ObjectRules(key, s3Src string) {
+	mapMutex.Lock()
+	removeKeyFromMapLocked(key, s3Src)
+	recordRulesLoadedMetric(redirectMap)
+	ruleReloadTotal.WithLabelValues("success").Inc()
+	mapMutex.Unlock()
+
+	id := s3KeyID{source: s3Src, key: key}
+	s3KeyHostsMu.Lock()
+	delete(s3KeyHosts, id)
+	s3KeyHostsMu.Unlock()
+
+	forgetS3SourceKey(s3Src, key)
+
+	s3ObjectETagsMu.Lock()
+	delete(s3ObjectETags, id)
+	s3ObjectETagsMu.Unlock()
+
+	log.Printf("Removed rules previously loaded from deleted S3 key %s", key)
+}
+
+// removeKeyFromMapLocked drops every rule tagged with Source == key, as
+// last loaded from s3Src, from redirectMap. Callers must hold mapMutex.
+func removeKeyFromMapLocked(key, s3Src string) {
+	s3KeyHostsMu.Lock()
+	hosts := s3KeyHosts[s3KeyID{source: s3Src, key: key}]
+	s3KeyHostsMu.Unlock()
+
+	for _, host := range hosts {
+		filtered := make([]Redirect, 0, len(redirectMap[host]))
+		for _, r := range redirectMap[host] {
+			if r.Source != key {
+				filtered = append(filtered, r)
+			}
+		}
+		if len(filtered) == 0 {
+			delete(redirectMap, host)
+		} else {
+			redirectMap[host] = filtered
+		}
+	}
+}
+
+// runS3ReconcileLoop periodically re-lists s3Src and reloads any key whose
+// ETag no longer matches what we last loaded, as a backstop against SQS
+// messages that were never delivered.
+func runS3ReconcileLoop(ctx context.Context, opts Options, s3Src string, changes chan<- Event) {
+	ticker := time.NewTicker(s3ReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reconcileS3ETags(ctx, opts, s3Src, changes)
+		}
+	}
+}
+
+func reconcileS3ETags(ctx context.Context, opts Options, s3Src string, changes chan<- Event) {
+	bucket, prefix := splitS3Path(s3Src)
+
+	client, err := newS3Client(ctx, opts)
+	if err != nil {
+		log.Printf("ERROR: reconciliation sweep could not load AWS config: %v", err)
+		return
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("ERROR: reconciliation sweep could not list s3://%s/%s: %v", bucket, prefix, err)
+			return
+		}
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if !strings.HasSuffix(strings.ToLower(key), ".csv") {
+				continue
+			}
+			etag := strings.Trim(aws.ToString(obj.ETag), `"`)
+
+			s3ObjectETagsMu.Lock()
+			known := s3ObjectETags[s3KeyID{source: s3Src, key: key}]
+			s3ObjectETagsMu.Unlock()
+
+			if known == etag {
+				continue
+			}
+			log.Printf("Reconciliation sweep detected drift for s3://%s/%s (etag %s -> %s)", bucket, key, known, etag)
+			if err := reloadS3Object(ctx, opts, bucket, key, s3Src); err != nil {
+				log.Printf("WARNING: reconciliation reload of %s failed: %v", key, err)
+				continue
+			}
+			notifyChange(changes, s3Src)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// RuleSource is anything Swerve can load redirect rules from: a local
+// directory, an S3 bucket/prefix, an HTTP(S) URL, or a git repository.
+// String() returns the source's --csv-src value (without any "git+" prefix),
+// for logging.
+type RuleSource interface {
+	Load(ctx context.Context) (map[string][]Redirect, int, []LoadIssue, error)
+
+	// Watch blocks until ctx is cancelled, sending an Event on changes
+	// whenever this source has a native push notification mechanism for
+	// upstream changes (S3 bucket notifications, for example). Sources
+	// without one return immediately; for those, --poll-interval is the
+	// only way changes are picked up.
+	Watch(ctx context.Context, changes chan<- Event)
+
+	fmt.Stringer
+}
+
+// Event reports that a RuleSource's underlying rules changed. Source
+// matches the RuleSource's String().
+type Event struct {
+	Source string
+}
+
+// notifyChange sends an Event on changes without blocking if the channel is
+// unbuffered/full or nil (Watch is best-effort notification, not a queue).
+func notifyChange(changes chan<- Event, source string) {
+	if changes == nil {
+		return
+	}
+	select {
+	case changes <- Event{Source: source}:
+	default:
+	}
+}
+
+// resolveSources maps each --csv-src value to the RuleSource implementation
+// that handles it, based on its scheme/suffix.
+func resolveSources(paths []string, opts Options) []RuleSource {
+	sources := make([]RuleSource, 0, len(paths))
+	for _, p := range paths {
+		switch {
+		case strings.HasPrefix(p, "s3://"):
+			sources = append(sources, &s3Source{path: p, opts: opts})
+		case strings.HasPrefix(p, "http://") || strings.HasPrefix(p, "https://"):
+			sources = append(sources, &httpSource{url: p})
+		case strings.HasPrefix(p, "git://") || strings.HasPrefix(p, "git+https://") || strings.HasSuffix(p, ".git"):
+			sources = append(sources, &gitSource{repoURL: strings.TrimPrefix(p, "git+")})
+		default:
+			sources = append(sources, &dirSource{path: p})
+		}
+	}
+	return sources
+}
+
+// countS3Sources returns how many entries in paths are s3:// sources.
+func countS3Sources(paths []string) int {
+	n := 0
+	for _, p := range paths {
+		if strings.HasPrefix(p, "s3://") {
+			n++
+		}
+	}
+	return n
+}
+
+// mergeSourceRules folds src into dest according to mode:
+//   - "append" (default): rules from every source defining a host are kept.
+//   - "override": the last source to define a host replaces any rules an
+//     earlier source contributed for it.
+//   - "error-on-conflict": it is an error for more than one source to define
+//     the same host.
+//
+// Sources must be merged in --csv-src order for "override" and
+// "error-on-conflict" to behave as documented.
+func mergeSourceRules(dest, src map[string][]Redirect, mode string) error {
+	for host, rules := range src {
+		switch mode {
+		case "override":
+			dest[host] = append([]Redirect(nil), rules...)
+		case "error-on-conflict":
+			if _, exists := dest[host]; exists {
+				return fmt.Errorf("merge conflict: host %q is defined by more than one --csv-src (merge-mode=error-on-conflict)", host)
+			}
+			dest[host] = append([]Redirect(nil), rules...)
+		default:
+			dest[host] = append(dest[host], rules...)
+		}
+	}
+	return nil
+}
+
+// dirSource loads rules from a local directory of .csv/.js files.
+type dirSource struct{ path string }
+
+func (s *dirSource) Load(ctx context.Context) (map[string][]Redirect, int, []LoadIssue, error) {
+	return loadRedirectsFromDir(s.path)
+}
+
+func (s *dirSource) String() string { return s.path }
+
+// Watch returns immediately: a local directory has no push notification
+// mechanism, so changes are only picked up by the --poll-interval ticker.
+func (s *dirSource) Watch(ctx context.Context, changes chan<- Event) {}
+
+// s3Source loads rules from an S3 bucket/prefix. Incremental reloads driven
+// by --s3-notification-queue run in Watch; Load is used for the initial load
+// and any full poll-interval reload.
+type s3Source struct {
+	path string
+	opts Options
+}
+
+func (s *s3Source) Load(ctx context.Context) (map[string][]Redirect, int, []LoadIssue, error) {
+	return loadRedirectsFromS3(s.path, s.opts)
+}
+
+func (s *s3Source) String() string { return s.path }
+
+// Watch subscribes to S3 bucket notifications via SQS when
+// --s3-notification-queue is configured, merging each changed object into
+// redirectMap incrementally (see watchS3Notifications) and sending an Event
+// per change. Without a notification queue it returns immediately, and
+// changes are only picked up by the --poll-interval ticker.
+func (s *s3Source) Watch(ctx context.Context, changes chan<- Event) {
+	if s.opts.S3NotificationQueue == "" {
+		return
+	}
+	watchS3Notifications(ctx, s.opts, s.opts.S3NotificationQueue, s.path, changes)
+}
+
+// httpSource fetches a CSV or JSON rule file over HTTP(S), using ETag /
+// If-None-Match to avoid re-parsing unchanged content.
+type httpSource struct {
+	url string
+
+	mu          sync.Mutex
+	lastETag    string
+	cachedRules map[string][]Redirect
+	cachedCount int
+}
+
+func (s *httpSource) Load(ctx context.Context) (map[string][]Redirect, int, []LoadIssue, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("could not build request for %s: %w", s.url, err)
+	}
+
+	s.mu.Lock()
+	etag := s.lastETag
+	s.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("could not fetch %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.cachedRules, s.cachedCount, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, nil, fmt.Errorf("unexpected status %s fetching %s", resp.Status, s.url)
+	}
+
+	var rules map[string][]Redirect
+	var count int
+	var issues []LoadIssue
+	if strings.HasSuffix(strings.ToLower(s.url), ".json") {
+		rules, count, issues, err = parseJSONRules(resp.Body, s.url)
+	} else {
+		rules, count, issues, err = parseRules(resp.Body, s.url)
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	s.mu.Lock()
+	s.lastETag = resp.Header.Get("ETag")
+	s.cachedRules = rules
+	s.cachedCount = count
+	s.mu.Unlock()
+
+	return rules, count, issues, nil
+}
+
+func (s *httpSource) String() string { return s.url }
+
+// Watch returns immediately: an HTTP(S) source has no push notification
+// mechanism, so changes are only picked up by the --poll-interval ticker,
+// where the ETag check in Load avoids re-parsing unchanged content.
+func (s *httpSource) Watch(ctx context.Context, changes chan<- Event) {}
+
+// jsonRedirect is the JSON counterpart of a CSV row, used by httpSource when
+// the fetched URL ends in .json.
+type jsonRedirect struct {
+	Host       string `json:"host"`
+	Type       string `json:"type"`
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	StatusCode int    `json:"statusCode"`
+	Weight     int    `json:"weight"`
+}
+
+// parseJSONRules decodes a JSON array of jsonRedirect objects into the same
+// map[string][]Redirect shape parseRules produces from CSV.
+func parseJSONRules(r io.Reader, sourceName string) (map[string][]Redirect, int, []LoadIssue, error) {
+	var raw []jsonRedirect
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, 0, nil, fmt.Errorf("could not parse JSON rules from %s: %w", sourceName, err)
+	}
+
+	rulesByHost := make(map[string][]Redirect)
+	count := 0
+	var issues []LoadIssue
+	for _, jr := range raw {
+		rule := Redirect{
+			SourceHost:        jr.Host,
+			MatchType:         jr.Type,
+			SourcePathOrRegex: jr.Source,
+			TargetURLFormat:   jr.Target,
+			StatusCode:        jr.StatusCode,
+			Weight:            jr.Weight,
+			Source:            sourceName,
+		}
+		if rule.MatchType == "regex" {
+			re, err := regexp.Compile(rule.SourcePathOrRegex)
+			if err != nil {
+				issues = append(issues, logIssue(sourceName, "%s: invalid regex %q, skipping rule: %v", sourceName, rule.SourcePathOrRegex, err))
+				continue
+			}
+			rule.Regex = re
+		}
+		rulesByHost[rule.SourceHost] = append(rulesByHost[rule.SourceHost], rule)
+		count++
+	}
+	return rulesByHost, count, issues, nil
+}
+
+// gitSource loads rules from a shallow clone of a git repository, pulling on
+// every Load() after the first.
+type gitSource struct {
+	repoURL string
+
+	mu  sync.Mutex
+	dir string
+}
+
+func (s *gitSource) Load(ctx context.Context) (map[string][]Redirect, int, []LoadIssue, error) {
+	dir, err := s.ensureClone(ctx)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return loadRedirectsFromDir(dir)
+}
+
+func (s *gitSource) String() string { return s.repoURL }
+
+// Watch returns immediately: a git source has no push notification
+// mechanism, so changes are only picked up by the --poll-interval ticker,
+// which pulls on every Load after the first.
+func (s *gitSource) Watch(ctx context.Context, changes chan<- Event) {}
+
+func (s *gitSource) ensureClone(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir != "" {
+		if err := runGit(ctx, s.dir, "pull", "--ff-only"); err != nil {
+			return "", fmt.Errorf("git pull failed for %s: %w", s.repoURL, err)
+		}
+		return s.dir, nil
+	}
+
+	dir, err := os.MkdirTemp("", "swerve-git-src-*")
+	if err != nil {
+		return "", fmt.Errorf("could not create temp dir for %s: %w", s.repoURL, err)
+	}
+	if err := runGit(ctx, "", "clone", "--depth=1", s.repoURL, dir); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("git clone failed for %s: %w", s.repoURL, err)
+	}
+	s.dir = dir
+	return dir, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
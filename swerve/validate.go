@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// ValidateCommand implements `swerve validate <src>`: it runs the same
+// loading pipeline as loadRules against a single source and prints a
+// structured report instead of starting the server, exiting non-zero if the
+// source has any errors.
+type ValidateCommand struct {
+	Src struct {
+		Path string `positional-arg-name:"src" description:"Rule source to validate (same syntax as --csv-src: a local path, an S3 URI, an http(s):// URL, or a git repo)."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// Execute implements go-flags' Commander interface.
+func (c *ValidateCommand) Execute(args []string) error {
+	report := validateSource(context.Background(), c.Src.Path, globalOpts)
+	printValidationReport(report)
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// ValidationReport is the structured result of validating one rule source.
+type ValidationReport struct {
+	Source     string
+	FileCounts map[string]int
+	TotalRules int
+	Errors     []string
+	Warnings   []string
+}
+
+// validateSource loads src through the normal RuleSource pipeline and adds
+// structural checks the loaders don't perform themselves: duplicate
+// (host, path) collisions, rules shadowed by a higher-weight match, and
+// target templates referencing capture groups their regex doesn't have.
+func validateSource(ctx context.Context, src string, opts Options) ValidationReport {
+	report := ValidationReport{Source: src, FileCounts: make(map[string]int)}
+
+	source := resolveSources([]string{src}, opts)[0]
+	rules, _, issues, err := source.Load(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+
+	for _, issue := range issues {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", issue.Source, issue.Message))
+	}
+
+	for host, hostRules := range rules {
+		for _, r := range hostRules {
+			report.FileCounts[r.Source]++
+			report.TotalRules++
+
+			if r.MatchType == "regex" && r.Regex != nil {
+				if unresolved := unresolvedCaptureGroups(r.Regex, r.TargetURLFormat); len(unresolved) > 0 {
+					report.Errors = append(report.Errors, fmt.Sprintf(
+						"%s: host %s, rule %q targets %q which references unresolved capture group(s) %v",
+						r.Source, host, r.SourcePathOrRegex, r.TargetURLFormat, unresolved))
+				}
+			}
+		}
+
+		report.Warnings = append(report.Warnings, checkShadowedRules(host, hostRules)...)
+	}
+
+	return report
+}
+
+// checkShadowedRules flags rules on a host that a higher-weight rule (the
+// one redirectHandler tries first) makes unreachable, or that tie with
+// another rule at the same weight so match order is undefined. It covers:
+//   - two "exact" rules for the same path
+//   - a lower-weight "exact" rule whose path is also matched by a
+//     higher-or-equal-weight "regex" rule
+//
+// Regex-vs-regex shadowing (one pattern being a strict superset of
+// another) isn't checked: pattern containment isn't decidable from the
+// rules alone the way a concrete exact path can be tested against a regex.
+func checkShadowedRules(host string, rules []Redirect) []string {
+	var warnings []string
+
+	byPath := make(map[string][]Redirect)
+	for _, r := range rules {
+		if r.MatchType == "exact" {
+			byPath[r.SourcePathOrRegex] = append(byPath[r.SourcePathOrRegex], r)
+		}
+	}
+	for path, group := range byPath {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Weight > group[j].Weight })
+		top := group[0].Weight
+		for _, r := range group[1:] {
+			if r.Weight == top {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: host %s, path %q has two rules at equal weight %d; match order is undefined",
+					r.Source, host, path, top))
+			} else {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: host %s, path %q rule at weight %d is unreachable, shadowed by a weight-%d rule",
+					r.Source, host, path, r.Weight, top))
+			}
+		}
+	}
+
+	for _, exact := range rules {
+		if exact.MatchType != "exact" {
+			continue
+		}
+		for _, higher := range rules {
+			if higher.MatchType != "regex" || higher.Regex == nil || higher.Weight < exact.Weight {
+				continue
+			}
+			if !higher.Regex.MatchString(exact.SourcePathOrRegex) {
+				continue
+			}
+			if higher.Weight == exact.Weight {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: host %s, exact path %q (weight %d) and regex rule %q (weight %d) both match; match order is undefined",
+					exact.Source, host, exact.SourcePathOrRegex, exact.Weight, higher.SourcePathOrRegex, higher.Weight))
+			} else {
+				warnings = append(warnings, fmt.Sprintf(
+					"%s: host %s, exact path %q (weight %d) is unreachable, shadowed by regex rule %q at weight %d",
+					exact.Source, host, exact.SourcePathOrRegex, exact.Weight, higher.SourcePathOrRegex, higher.Weight))
+			}
+			break // one shadow warning per exact rule is enough
+		}
+	}
+
+	return warnings
+}
+
+// unresolvedCaptureGroups returns the $N references in target that re has no
+// matching capture group for.
+func unresolvedCaptureGroups(re *regexp.Regexp, target string) []string {
+	groups := re.NumSubexp()
+
+	var unresolved []string
+	for _, m := range regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(target, -1) {
+		n := 0
+		fmt.Sscanf(m[1], "%d", &n)
+		if n > groups {
+			unresolved = append(unresolved, m[0])
+		}
+	}
+	return unresolved
+}
+
+func printValidationReport(report ValidationReport) {
+	fmt.Printf("Validation report for %s\n", report.Source)
+	fmt.Printf("  %d rule(s) loaded\n", report.TotalRules)
+
+	files := make([]string, 0, len(report.FileCounts))
+	for f := range report.FileCounts {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		fmt.Printf("    %s: %d rule(s)\n", f, report.FileCounts[f])
+	}
+
+	if len(report.Warnings) == 0 && len(report.Errors) == 0 {
+		fmt.Println("  No issues found.")
+		return
+	}
+
+	for _, w := range report.Warnings {
+		fmt.Printf("  WARNING: %s\n", w)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("  ERROR: %s\n", e)
+	}
+}